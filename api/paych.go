@@ -0,0 +1,31 @@
+package api
+
+import "github.com/filecoin-project/go-lotus/chain/types"
+
+// PaychStatus reports the current state of a payment channel: its balance,
+// how much of that balance has already been redeemed, its settlement
+// state, and the nonce reached on each lane. It is returned by PaychStatus.
+type PaychStatus struct {
+	Balance    types.BigInt
+	Redeemed   types.BigInt
+	SettlingAt uint64
+	Lanes      []LaneStatus
+}
+
+// LaneStatus reports the nonce reached on a single payment channel lane.
+type LaneStatus struct {
+	Lane  uint64
+	Nonce uint64
+}
+
+// BundleFormat selects the encoding used by PaychVoucherExport/Import for a
+// batch of vouchers.
+type BundleFormat string
+
+const (
+	// BundleFormatJSON is the default, human-readable bundle encoding.
+	BundleFormatJSON BundleFormat = "json"
+	// BundleFormatCBOR is a compact binary bundle encoding, useful when
+	// backing up voucher state for many concurrent channels.
+	BundleFormatCBOR BundleFormat = "cbor"
+)
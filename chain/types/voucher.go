@@ -0,0 +1,88 @@
+package types
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+
+	"github.com/filecoin-project/go-lotus/chain/address"
+)
+
+// Merge describes another lane being folded into this voucher, carrying
+// forward the nonce it had already reached so the channel actor can accept
+// the combined state without replaying every intermediate voucher on that
+// lane.
+type Merge struct {
+	Lane  uint64
+	Nonce uint64
+}
+
+// SignedVoucher is a voucher for a payment channel lane, signed by the
+// channel's "from" account. Besides moving a monotonically increasing
+// amount, a voucher may carry HTLC-style conditions: a window of heights
+// during which it may be redeemed, a hash of a secret preimage that must be
+// revealed to redeem it, and other lanes to merge into this one.
+type SignedVoucher struct {
+	ChannelAddr address.Address
+
+	TimeLockMin uint64
+	TimeLockMax uint64
+
+	SecretPreimage []byte
+
+	Lane   uint64
+	Nonce  uint64
+	Amount BigInt
+
+	MinSettleHeight uint64
+	Merges          []Merge
+
+	Signature *Signature
+}
+
+// EncodedString returns a base64 encoded, serialized representation of the
+// voucher, suitable for passing around out of band (chat, email, a CLI
+// argument).
+func (sv *SignedVoucher) EncodedString() (string, error) {
+	b, err := json.Marshal(sv)
+	if err != nil {
+		return "", err
+	}
+
+	return base64.StdEncoding.EncodeToString(b), nil
+}
+
+// DecodeSignedVoucher parses a voucher produced by EncodedString.
+func DecodeSignedVoucher(s string) (*SignedVoucher, error) {
+	b, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode voucher: %s", err)
+	}
+
+	var sv SignedVoucher
+	if err := json.Unmarshal(b, &sv); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal voucher: %s", err)
+	}
+
+	return &sv, nil
+}
+
+// VoucherCreateParams carries the optional conditions that can be attached
+// to a voucher at creation time: a height before which it cannot be
+// redeemed, a height before which the channel cannot be closed, a hash of a
+// secret preimage that must be revealed to redeem it, and any other lanes
+// being merged into this one.
+type VoucherCreateParams struct {
+	TimeLock       uint64
+	MinCloseHeight uint64
+	SecretHash     []byte
+	Merges         []Merge
+}
+
+// Apply copies the conditional fields from p onto sv.
+func (p VoucherCreateParams) Apply(sv *SignedVoucher) {
+	sv.TimeLockMin = p.TimeLock
+	sv.MinSettleHeight = p.MinCloseHeight
+	sv.SecretPreimage = p.SecretHash
+	sv.Merges = p.Merges
+}
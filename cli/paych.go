@@ -1,8 +1,14 @@
 package cli
 
 import (
+	"encoding/hex"
 	"fmt"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
 
+	"github.com/filecoin-project/go-lotus/api"
 	"github.com/filecoin-project/go-lotus/chain/address"
 	types "github.com/filecoin-project/go-lotus/chain/types"
 	"gopkg.in/urfave/cli.v2"
@@ -14,6 +20,9 @@ var paychCmd = &cli.Command{
 	Subcommands: []*cli.Command{
 		paychCreateCmd,
 		paychListCmd,
+		paychSettleCmd,
+		paychCloseCmd,
+		paychStatusCmd,
 		paychVoucherCmd,
 	},
 }
@@ -81,6 +90,126 @@ var paychListCmd = &cli.Command{
 	},
 }
 
+var paychSettleCmd = &cli.Command{
+	Name:  "settle",
+	Usage: "Settle a payment channel, starting its dispute window",
+	Action: func(cctx *cli.Context) error {
+		if cctx.Args().Len() != 1 {
+			return fmt.Errorf("must pass payment channel address")
+		}
+
+		ch, err := address.NewFromString(cctx.Args().Get(0))
+		if err != nil {
+			return err
+		}
+
+		api, err := GetFullNodeAPI(cctx)
+		if err != nil {
+			return err
+		}
+
+		ctx := ReqContext(cctx)
+
+		mcid, err := api.PaychSettle(ctx, ch)
+		if err != nil {
+			return err
+		}
+
+		mwait, err := api.ChainWaitMsg(ctx, mcid)
+		if err != nil {
+			return err
+		}
+
+		if mwait.Receipt.ExitCode != 0 {
+			return fmt.Errorf("message execution failed (exit code %d)", mwait.Receipt.ExitCode)
+		}
+
+		fmt.Println("channel settled")
+
+		return nil
+	},
+}
+
+var paychCloseCmd = &cli.Command{
+	Name:  "close",
+	Usage: "Close a payment channel once its dispute window has passed",
+	Action: func(cctx *cli.Context) error {
+		if cctx.Args().Len() != 1 {
+			return fmt.Errorf("must pass payment channel address")
+		}
+
+		ch, err := address.NewFromString(cctx.Args().Get(0))
+		if err != nil {
+			return err
+		}
+
+		api, err := GetFullNodeAPI(cctx)
+		if err != nil {
+			return err
+		}
+
+		ctx := ReqContext(cctx)
+
+		mcid, err := api.PaychCollect(ctx, ch)
+		if err != nil {
+			return err
+		}
+
+		mwait, err := api.ChainWaitMsg(ctx, mcid)
+		if err != nil {
+			return err
+		}
+
+		if mwait.Receipt.ExitCode != 0 {
+			return fmt.Errorf("message execution failed (exit code %d)", mwait.Receipt.ExitCode)
+		}
+
+		fmt.Println("channel closed")
+
+		return nil
+	},
+}
+
+var paychStatusCmd = &cli.Command{
+	Name:  "status",
+	Usage: "Show the balance, settlement state, and lanes of a payment channel",
+	Action: func(cctx *cli.Context) error {
+		if cctx.Args().Len() != 1 {
+			return fmt.Errorf("must pass payment channel address")
+		}
+
+		ch, err := address.NewFromString(cctx.Args().Get(0))
+		if err != nil {
+			return err
+		}
+
+		api, err := GetFullNodeAPI(cctx)
+		if err != nil {
+			return err
+		}
+
+		ctx := ReqContext(cctx)
+
+		status, err := api.PaychStatus(ctx, ch)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("Balance: %s\n", status.Balance)
+		fmt.Printf("Redeemed: %s\n", status.Redeemed)
+		if status.SettlingAt == 0 {
+			fmt.Println("Settling at: not settling")
+		} else {
+			fmt.Printf("Settling at: %d\n", status.SettlingAt)
+		}
+		for _, l := range status.Lanes {
+			fmt.Printf("Lane %d: nonce %d\n", l.Lane, l.Nonce)
+		}
+
+		return nil
+	},
+}
+
 var paychVoucherCmd = &cli.Command{
 	Name:  "voucher",
 	Usage: "Interact with payment channel vouchers",
@@ -91,19 +220,145 @@ var paychVoucherCmd = &cli.Command{
 		paychVoucherListCmd,
 		paychVoucherBestSpendableCmd,
 		paychVoucherSubmitCmd,
+		paychVoucherRedeemCmd,
+		paychVoucherExportCmd,
+		paychVoucherImportCmd,
+		paychVoucherCreateUnsignedCmd,
+		paychVoucherSignOfflineCmd,
 	},
 }
 
-var paychVoucherCreateCmd = &cli.Command{
-	Name:  "create",
-	Usage: "Create a signed payment channel voucher",
-	Flags: []cli.Flag{
+// bundleFormatFlag is shared by the export and import commands.
+var bundleFormatFlag = &cli.StringFlag{
+	Name:  "format",
+	Value: string(api.BundleFormatJSON),
+	Usage: "bundle encoding to use (json or cbor)",
+}
+
+// nonNegativeInt64 reads an int64 flag and rejects negative values, so a typo
+// like "-1" produces a clear CLI error instead of silently wrapping around
+// when later cast to a uint64.
+func nonNegativeInt64(cctx *cli.Context, name string) (uint64, error) {
+	v := cctx.Int64(name)
+	if v < 0 {
+		return 0, fmt.Errorf("%s must not be negative, got %d", name, v)
+	}
+	return uint64(v), nil
+}
+
+// nonNegativeInt reads an int flag and rejects negative values, same as
+// nonNegativeInt64 but for flags (like --lane) declared as IntFlag.
+func nonNegativeInt(cctx *cli.Context, name string) (int, error) {
+	v := cctx.Int(name)
+	if v < 0 {
+		return 0, fmt.Errorf("%s must not be negative, got %d", name, v)
+	}
+	return v, nil
+}
+
+// parseMerges parses a comma separated list of "<lane>:<nonce>" pairs into
+// the lane merges a voucher should carry, so that redeeming it also settles
+// the given lanes into this one.
+func parseMerges(s string) ([]types.Merge, error) {
+	if s == "" {
+		return nil, nil
+	}
+
+	var out []types.Merge
+	for _, m := range strings.Split(s, ",") {
+		parts := strings.Split(m, ":")
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid merge %q, expected <lane>:<nonce>", m)
+		}
+
+		lane, err := strconv.ParseUint(parts[0], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid merge lane %q: %s", parts[0], err)
+		}
+
+		nonce, err := strconv.ParseUint(parts[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid merge nonce %q: %s", parts[1], err)
+		}
+
+		out = append(out, types.Merge{Lane: lane, Nonce: nonce})
+	}
+
+	return out, nil
+}
+
+// voucherConditionFlags are the flags shared by any command that creates a
+// voucher (signed or unsigned): the lane to use, and the HTLC-style
+// conditions it may carry.
+func voucherConditionFlags() []cli.Flag {
+	return []cli.Flag{
 		&cli.IntFlag{
 			Name:  "lane",
 			Value: 0,
 			Usage: "specify payment channel lane to use",
 		},
-	},
+		&cli.Int64Flag{
+			Name:  "time-lock",
+			Usage: "set a height before which the voucher cannot be redeemed",
+		},
+		&cli.Int64Flag{
+			Name:  "min-close-height",
+			Usage: "prevent the channel from closing before the given height",
+		},
+		&cli.StringFlag{
+			Name:  "secret-hash",
+			Usage: "hex encoded hash of the secret preimage required to redeem the voucher",
+		},
+		&cli.StringFlag{
+			Name:  "merges",
+			Usage: "comma separated list of <lane>:<nonce> to merge into this voucher",
+		},
+	}
+}
+
+// voucherConditionParams reads the flags set up by voucherConditionFlags
+// into a lane number and the conditions to attach to the voucher.
+func voucherConditionParams(cctx *cli.Context) (int, types.VoucherCreateParams, error) {
+	lane, err := nonNegativeInt(cctx, "lane")
+	if err != nil {
+		return 0, types.VoucherCreateParams{}, err
+	}
+
+	var secretHash []byte
+	if s := cctx.String("secret-hash"); s != "" {
+		secretHash, err = hex.DecodeString(s)
+		if err != nil {
+			return 0, types.VoucherCreateParams{}, fmt.Errorf("failed to parse secret-hash: %s", err)
+		}
+	}
+
+	merges, err := parseMerges(cctx.String("merges"))
+	if err != nil {
+		return 0, types.VoucherCreateParams{}, err
+	}
+
+	timeLock, err := nonNegativeInt64(cctx, "time-lock")
+	if err != nil {
+		return 0, types.VoucherCreateParams{}, err
+	}
+
+	minCloseHeight, err := nonNegativeInt64(cctx, "min-close-height")
+	if err != nil {
+		return 0, types.VoucherCreateParams{}, err
+	}
+
+	return lane, types.VoucherCreateParams{
+		TimeLock:       timeLock,
+		MinCloseHeight: minCloseHeight,
+		SecretHash:     secretHash,
+		Merges:         merges,
+	}, nil
+}
+
+var paychVoucherCreateCmd = &cli.Command{
+	Name:  "create",
+	Usage: "Create a signed payment channel voucher",
+	Flags: voucherConditionFlags(),
 	Action: func(cctx *cli.Context) error {
 		if cctx.Args().Len() != 2 {
 			return fmt.Errorf("must pass two arguments: <channel> <amount>")
@@ -119,7 +374,10 @@ var paychVoucherCreateCmd = &cli.Command{
 			return err
 		}
 
-		lane := cctx.Int("lane")
+		lane, extra, err := voucherConditionParams(cctx)
+		if err != nil {
+			return err
+		}
 
 		api, err := GetFullNodeAPI(cctx)
 		if err != nil {
@@ -128,7 +386,7 @@ var paychVoucherCreateCmd = &cli.Command{
 
 		ctx := ReqContext(cctx)
 
-		sv, err := api.PaychVoucherCreate(ctx, ch, amt, uint64(lane))
+		sv, err := api.PaychVoucherCreate(ctx, ch, amt, uint64(lane), extra)
 		if err != nil {
 			return err
 		}
@@ -321,7 +579,7 @@ var paychVoucherSubmitCmd = &cli.Command{
 
 		ctx := ReqContext(cctx)
 
-		mcid, err := api.PaychVoucherSubmit(ctx, ch, sv)
+		mcid, err := api.PaychVoucherSubmit(ctx, ch, sv, nil, nil)
 		if err != nil {
 			return err
 		}
@@ -339,4 +597,221 @@ var paychVoucherSubmitCmd = &cli.Command{
 
 		return nil
 	},
-}
\ No newline at end of file
+}
+
+var paychVoucherRedeemCmd = &cli.Command{
+	Name:  "redeem",
+	Usage: "Redeem a conditional voucher by revealing its secret preimage",
+	Action: func(cctx *cli.Context) error {
+		if cctx.Args().Len() != 3 {
+			return fmt.Errorf("must pass payment channel address, voucher, and secret")
+		}
+
+		ch, err := address.NewFromString(cctx.Args().Get(0))
+		if err != nil {
+			return err
+		}
+
+		sv, err := types.DecodeSignedVoucher(cctx.Args().Get(1))
+		if err != nil {
+			return err
+		}
+
+		secret, err := hex.DecodeString(cctx.Args().Get(2))
+		if err != nil {
+			return fmt.Errorf("failed to parse secret: %s", err)
+		}
+
+		api, err := GetFullNodeAPI(cctx)
+		if err != nil {
+			return err
+		}
+
+		ctx := ReqContext(cctx)
+
+		mcid, err := api.PaychVoucherSubmit(ctx, ch, sv, secret, nil)
+		if err != nil {
+			return err
+		}
+
+		mwait, err := api.ChainWaitMsg(ctx, mcid)
+		if err != nil {
+			return err
+		}
+
+		if mwait.Receipt.ExitCode != 0 {
+			return fmt.Errorf("message execution failed (exit code %d)", mwait.Receipt.ExitCode)
+		}
+
+		fmt.Println("voucher redeemed succesfully")
+
+		return nil
+	},
+}
+
+var paychVoucherExportCmd = &cli.Command{
+	Name:      "export",
+	Usage:     "Export every stored voucher for a payment channel as a single bundle",
+	ArgsUsage: "<channel>",
+	Flags: []cli.Flag{
+		bundleFormatFlag,
+		&cli.StringFlag{
+			Name:  "output",
+			Usage: "write the bundle to a file instead of stdout",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		if cctx.Args().Len() != 1 {
+			return fmt.Errorf("must pass payment channel address")
+		}
+
+		ch, err := address.NewFromString(cctx.Args().Get(0))
+		if err != nil {
+			return err
+		}
+
+		fnapi, err := GetFullNodeAPI(cctx)
+		if err != nil {
+			return err
+		}
+
+		ctx := ReqContext(cctx)
+
+		bundle, err := fnapi.PaychVoucherExport(ctx, ch, api.BundleFormat(cctx.String("format")))
+		if err != nil {
+			return err
+		}
+
+		if out := cctx.String("output"); out != "" {
+			return ioutil.WriteFile(out, bundle, 0644)
+		}
+
+		// The bundle may be a binary CBOR encoding, so write its raw bytes
+		// rather than going through fmt, which would mangle non-UTF8 bytes
+		// and append a trailing newline.
+		_, err = os.Stdout.Write(bundle)
+		return err
+	},
+}
+
+var paychVoucherImportCmd = &cli.Command{
+	Name:      "import",
+	Usage:     "Import a voucher bundle produced by paych voucher export",
+	ArgsUsage: "<file>",
+	Flags: []cli.Flag{
+		bundleFormatFlag,
+	},
+	Action: func(cctx *cli.Context) error {
+		if cctx.Args().Len() != 1 {
+			return fmt.Errorf("must pass path to a voucher bundle")
+		}
+
+		bundle, err := ioutil.ReadFile(cctx.Args().Get(0))
+		if err != nil {
+			return fmt.Errorf("failed to read bundle: %s", err)
+		}
+
+		fnapi, err := GetFullNodeAPI(cctx)
+		if err != nil {
+			return err
+		}
+
+		ctx := ReqContext(cctx)
+
+		if err := fnapi.PaychVoucherImport(ctx, bundle, api.BundleFormat(cctx.String("format"))); err != nil {
+			return err
+		}
+
+		fmt.Println("bundle imported")
+
+		return nil
+	},
+}
+
+var paychVoucherCreateUnsignedCmd = &cli.Command{
+	Name:      "create-unsigned",
+	Usage:     "Create an unsigned voucher for offline or HSM signing",
+	ArgsUsage: "<channel> <amount>",
+	Flags:     voucherConditionFlags(),
+	Action: func(cctx *cli.Context) error {
+		if cctx.Args().Len() != 2 {
+			return fmt.Errorf("must pass two arguments: <channel> <amount>")
+		}
+
+		ch, err := address.NewFromString(cctx.Args().Get(0))
+		if err != nil {
+			return err
+		}
+
+		amt, err := types.BigFromString(cctx.Args().Get(1))
+		if err != nil {
+			return err
+		}
+
+		lane, extra, err := voucherConditionParams(cctx)
+		if err != nil {
+			return err
+		}
+
+		fnapi, err := GetFullNodeAPI(cctx)
+		if err != nil {
+			return err
+		}
+
+		ctx := ReqContext(cctx)
+
+		sv, err := fnapi.PaychVoucherCreateUnsigned(ctx, ch, amt, uint64(lane), extra)
+		if err != nil {
+			return err
+		}
+
+		enc, err := sv.EncodedString()
+		if err != nil {
+			return err
+		}
+
+		fmt.Println(enc)
+		return nil
+	},
+}
+
+var paychVoucherSignOfflineCmd = &cli.Command{
+	Name:      "sign-offline",
+	Usage:     "Attach an externally produced signature to an unsigned voucher",
+	ArgsUsage: "<unsigned voucher> <hex signature>",
+	Action: func(cctx *cli.Context) error {
+		if cctx.Args().Len() != 2 {
+			return fmt.Errorf("must pass two arguments: <unsigned voucher> <hex signature>")
+		}
+
+		sv, err := types.DecodeSignedVoucher(cctx.Args().Get(0))
+		if err != nil {
+			return err
+		}
+
+		sigBytes, err := hex.DecodeString(cctx.Args().Get(1))
+		if err != nil {
+			return fmt.Errorf("failed to parse signature: %s", err)
+		}
+
+		fnapi, err := GetFullNodeAPI(cctx)
+		if err != nil {
+			return err
+		}
+
+		ctx := ReqContext(cctx)
+
+		signed, err := fnapi.PaychVoucherAttachSignature(ctx, sv, sigBytes)
+		if err != nil {
+			return err
+		}
+
+		enc, err := signed.EncodedString()
+		if err != nil {
+			return err
+		}
+
+		fmt.Println(enc)
+		return nil
+	},
+}
@@ -0,0 +1,133 @@
+package paychmgr
+
+import (
+	"context"
+	"time"
+
+	"github.com/ipfs/go-cid"
+	logging "github.com/ipfs/go-log"
+
+	"github.com/filecoin-project/go-lotus/api"
+	"github.com/filecoin-project/go-lotus/chain/address"
+	"github.com/filecoin-project/go-lotus/chain/types"
+)
+
+var log = logging.Logger("paychmgr")
+
+// PaychAPI is the subset of the full node API the auto-submit service needs.
+// It is satisfied by api.FullNode without this package depending on the rest
+// of that interface.
+type PaychAPI interface {
+	PaychList(ctx context.Context) ([]address.Address, error)
+	PaychStatus(ctx context.Context, ch address.Address) (*api.PaychStatus, error)
+	PaychVoucherList(ctx context.Context, ch address.Address) ([]*types.SignedVoucher, error)
+	PaychVoucherCheckSpendable(ctx context.Context, ch address.Address, sv *types.SignedVoucher, secret []byte, proof []byte) (bool, error)
+	PaychVoucherSubmit(ctx context.Context, ch address.Address, sv *types.SignedVoucher, secret []byte, proof []byte) (cid.Cid, error)
+}
+
+// PollInterval is how often AutoSubmit checks registered channels for an
+// approaching settlement window.
+const PollInterval = 10 * time.Second
+
+// AutoSubmit watches locally stored vouchers for every registered payment
+// channel and, once a channel enters its settling window, submits the best
+// spendable voucher on each lane before the dispute window closes. It backs
+// the opt-in `lotus daemon --paych-auto-submit` service: a user who forgets
+// to submit their best voucher before a counterparty settles would otherwise
+// lose the difference between their last submitted nonce and the one they
+// hold.
+type AutoSubmit struct {
+	api PaychAPI
+}
+
+// NewAutoSubmit constructs an AutoSubmit service over api.
+func NewAutoSubmit(api PaychAPI) *AutoSubmit {
+	return &AutoSubmit{api: api}
+}
+
+// Run polls registered channels every PollInterval until ctx is cancelled.
+// A channel that fails to check or submit is logged and skipped - it never
+// stops the poller from servicing the rest of the registered channels.
+func (as *AutoSubmit) Run(ctx context.Context) error {
+	t := time.NewTicker(PollInterval)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-t.C:
+			as.checkAll(ctx)
+		}
+	}
+}
+
+func (as *AutoSubmit) checkAll(ctx context.Context) {
+	chs, err := as.api.PaychList(ctx)
+	if err != nil {
+		log.Errorf("paych auto-submit: listing channels: %s", err)
+		return
+	}
+
+	for _, ch := range chs {
+		if err := as.checkChannel(ctx, ch); err != nil {
+			log.Errorf("paych auto-submit: channel %s: %s", ch, err)
+		}
+	}
+}
+
+func (as *AutoSubmit) checkChannel(ctx context.Context, ch address.Address) error {
+	status, err := as.api.PaychStatus(ctx, ch)
+	if err != nil {
+		return err
+	}
+
+	if status.SettlingAt == 0 {
+		// Not settling yet, nothing to do.
+		return nil
+	}
+
+	best, err := BestSpendableByLane(ctx, as.api, ch)
+	if err != nil {
+		return err
+	}
+
+	for _, sv := range best {
+		if _, err := as.api.PaychVoucherSubmit(ctx, ch, sv, nil, nil); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// BestSpendableByLane returns the highest-value currently spendable voucher
+// stored for each lane of ch.
+func BestSpendableByLane(ctx context.Context, api PaychAPI, ch address.Address) ([]*types.SignedVoucher, error) {
+	vouchers, err := api.PaychVoucherList(ctx, ch)
+	if err != nil {
+		return nil, err
+	}
+
+	best := map[uint64]*types.SignedVoucher{}
+	for _, v := range vouchers {
+		spendable, err := api.PaychVoucherCheckSpendable(ctx, ch, v, nil, nil)
+		if err != nil {
+			return nil, err
+		}
+		if !spendable {
+			continue
+		}
+
+		if cur, ok := best[v.Lane]; !ok || types.BigCmp(v.Amount, cur.Amount) > 0 {
+			best[v.Lane] = v
+		}
+	}
+
+	out := make([]*types.SignedVoucher, 0, len(best))
+	for _, v := range best {
+		out = append(out, v)
+	}
+
+	return out, nil
+}
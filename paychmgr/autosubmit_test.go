@@ -0,0 +1,111 @@
+package paychmgr
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/ipfs/go-cid"
+
+	"github.com/filecoin-project/go-lotus/api"
+	"github.com/filecoin-project/go-lotus/chain/address"
+	"github.com/filecoin-project/go-lotus/chain/types"
+)
+
+func TestBestSpendableByLanePicksHighestAmountPerLane(t *testing.T) {
+	low := &types.SignedVoucher{Lane: 0, Nonce: 1, Amount: types.NewInt(10)}
+	high := &types.SignedVoucher{Lane: 0, Nonce: 2, Amount: types.NewInt(20)}
+	otherLane := &types.SignedVoucher{Lane: 1, Nonce: 1, Amount: types.NewInt(5)}
+	unspendable := &types.SignedVoucher{Lane: 2, Nonce: 1, Amount: types.NewInt(1000)}
+
+	fake := &fakePaychAPI{
+		vouchers:    []*types.SignedVoucher{low, high, otherLane, unspendable},
+		unspendable: map[*types.SignedVoucher]bool{unspendable: true},
+	}
+
+	best, err := BestSpendableByLane(context.Background(), fake, address.Address{})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	byLane := map[uint64]*types.SignedVoucher{}
+	for _, sv := range best {
+		byLane[sv.Lane] = sv
+	}
+
+	if byLane[0] != high {
+		t.Fatalf("expected the higher-amount voucher on lane 0, got %v", byLane[0])
+	}
+	if byLane[1] != otherLane {
+		t.Fatalf("expected the only spendable voucher on lane 1, got %v", byLane[1])
+	}
+	if _, ok := byLane[2]; ok {
+		t.Fatal("expected the unspendable voucher's lane to be skipped")
+	}
+}
+
+func TestCheckAllSkipsFailingChannelsAndContinues(t *testing.T) {
+	chs := []address.Address{{}, {}}
+	fake := &fakePaychAPI{
+		channels:  chs,
+		statusErr: map[int]error{0: fmt.Errorf("transient RPC hiccup")},
+		statuses:  map[int]*api.PaychStatus{1: {SettlingAt: 100}},
+		vouchers:  []*types.SignedVoucher{{Lane: 0, Nonce: 1, Amount: types.NewInt(1)}},
+	}
+
+	as := NewAutoSubmit(fake)
+	as.checkAll(context.Background())
+
+	if fake.statusCalls != 2 {
+		t.Fatalf("expected both channels to be checked despite the first failing, got %d calls", fake.statusCalls)
+	}
+	if fake.submitCalls != 1 {
+		t.Fatalf("expected the settling channel's best voucher to be submitted, got %d calls", fake.submitCalls)
+	}
+}
+
+// fakePaychAPI is a minimal PaychAPI for tests. Status/error behaviour for
+// checkAll tests is keyed by call index rather than by address, since
+// address.Address values can't be meaningfully distinguished without the
+// real address package's constructors.
+type fakePaychAPI struct {
+	channels  []address.Address
+	statusErr map[int]error
+	statuses  map[int]*api.PaychStatus
+
+	vouchers    []*types.SignedVoucher
+	unspendable map[*types.SignedVoucher]bool
+
+	statusCalls int
+	submitCalls int
+}
+
+func (f *fakePaychAPI) PaychList(ctx context.Context) ([]address.Address, error) {
+	return f.channels, nil
+}
+
+func (f *fakePaychAPI) PaychStatus(ctx context.Context, ch address.Address) (*api.PaychStatus, error) {
+	i := f.statusCalls
+	f.statusCalls++
+
+	if err, ok := f.statusErr[i]; ok {
+		return nil, err
+	}
+	if st, ok := f.statuses[i]; ok {
+		return st, nil
+	}
+	return &api.PaychStatus{}, nil
+}
+
+func (f *fakePaychAPI) PaychVoucherList(ctx context.Context, ch address.Address) ([]*types.SignedVoucher, error) {
+	return f.vouchers, nil
+}
+
+func (f *fakePaychAPI) PaychVoucherCheckSpendable(ctx context.Context, ch address.Address, sv *types.SignedVoucher, secret []byte, proof []byte) (bool, error) {
+	return !f.unspendable[sv], nil
+}
+
+func (f *fakePaychAPI) PaychVoucherSubmit(ctx context.Context, ch address.Address, sv *types.SignedVoucher, secret []byte, proof []byte) (cid.Cid, error) {
+	f.submitCalls++
+	return cid.Undef, nil
+}
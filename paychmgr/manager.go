@@ -0,0 +1,53 @@
+package paychmgr
+
+import (
+	"context"
+
+	"github.com/filecoin-project/go-lotus/chain/types"
+)
+
+// ChainHeightAPI is the subset of the full node API needed to learn the
+// current chain epoch, used when validating a voucher's time-lock.
+type ChainHeightAPI interface {
+	ChainHead(ctx context.Context) (uint64, error)
+}
+
+// Manager backs the node's PaychVoucherCheckValid and PaychVoucherSubmit API
+// methods: it enforces a conditional voucher's time-lock and, on submit,
+// that a revealed secret matches its preimage commitment, before the
+// voucher is accepted or the node lets it be submitted on chain.
+type Manager struct {
+	api ChainHeightAPI
+}
+
+// NewManager constructs a Manager over api.
+func NewManager(api ChainHeightAPI) *Manager {
+	return &Manager{api: api}
+}
+
+// CheckVoucherValid backs PaychVoucherCheckValid: it confirms sv's time-lock
+// has opened (and hasn't expired) at the current chain height.
+func (m *Manager) CheckVoucherValid(ctx context.Context, sv *types.SignedVoucher) error {
+	height, err := m.api.ChainHead(ctx)
+	if err != nil {
+		return err
+	}
+
+	// No secret is revealed by a plain validity check, so the preimage
+	// commitment is left for SubmitVoucher to enforce.
+	cond := *sv
+	cond.SecretPreimage = nil
+	return ValidateConditions(&cond, height, nil)
+}
+
+// SubmitVoucher backs PaychVoucherSubmit: besides the time-lock check, it
+// confirms a revealed secret (e.g. from 'paych voucher redeem') matches sv's
+// preimage commitment before the voucher is submitted on chain.
+func (m *Manager) SubmitVoucher(ctx context.Context, sv *types.SignedVoucher, secret []byte) error {
+	height, err := m.api.ChainHead(ctx)
+	if err != nil {
+		return err
+	}
+
+	return ValidateConditions(sv, height, secret)
+}
@@ -0,0 +1,80 @@
+package paychmgr
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/fxamacker/cbor/v2"
+
+	"github.com/filecoin-project/go-lotus/api"
+	"github.com/filecoin-project/go-lotus/chain/address"
+	"github.com/filecoin-project/go-lotus/chain/types"
+)
+
+// VoucherBundle is every voucher stored locally for a single payment
+// channel, exported together so it can be backed up or moved between nodes.
+type VoucherBundle struct {
+	Channel  address.Address
+	Vouchers []*types.SignedVoucher
+}
+
+// CreateUnsigned builds a voucher carrying params' conditions but leaves its
+// Signature nil, so it can be handed to an external signer (a cold wallet or
+// an HSM) rather than the node's own keystore.
+func CreateUnsigned(ch address.Address, amount types.BigInt, lane uint64, nonce uint64, params types.VoucherCreateParams) *types.SignedVoucher {
+	sv := &types.SignedVoucher{
+		ChannelAddr: ch,
+		Lane:        lane,
+		Nonce:       nonce,
+		Amount:      amount,
+	}
+	params.Apply(sv)
+	return sv
+}
+
+// AttachSignature attaches a signature produced by an external signer to an
+// unsigned voucher, returning the now-redeemable voucher.
+func AttachSignature(sv *types.SignedVoucher, sig *types.Signature) (*types.SignedVoucher, error) {
+	if sig == nil {
+		return nil, fmt.Errorf("signature must not be nil")
+	}
+
+	signed := *sv
+	signed.Signature = sig
+	return &signed, nil
+}
+
+// ExportBundle serializes every voucher stored for ch into a single bundle,
+// encoded as format.
+func ExportBundle(ch address.Address, vouchers []*types.SignedVoucher, format api.BundleFormat) ([]byte, error) {
+	bundle := VoucherBundle{Channel: ch, Vouchers: vouchers}
+
+	switch format {
+	case "", api.BundleFormatJSON:
+		return json.Marshal(bundle)
+	case api.BundleFormatCBOR:
+		return cbor.Marshal(bundle)
+	default:
+		return nil, fmt.Errorf("unknown bundle format %q", format)
+	}
+}
+
+// ImportBundle parses a bundle produced by ExportBundle.
+func ImportBundle(b []byte, format api.BundleFormat) (*VoucherBundle, error) {
+	var bundle VoucherBundle
+
+	switch format {
+	case "", api.BundleFormatJSON:
+		if err := json.Unmarshal(b, &bundle); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal voucher bundle: %s", err)
+		}
+	case api.BundleFormatCBOR:
+		if err := cbor.Unmarshal(b, &bundle); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal voucher bundle: %s", err)
+		}
+	default:
+		return nil, fmt.Errorf("unknown bundle format %q", format)
+	}
+
+	return &bundle, nil
+}
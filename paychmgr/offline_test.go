@@ -0,0 +1,78 @@
+package paychmgr
+
+import (
+	"testing"
+
+	"github.com/filecoin-project/go-lotus/api"
+	"github.com/filecoin-project/go-lotus/chain/address"
+	"github.com/filecoin-project/go-lotus/chain/types"
+)
+
+func TestCreateUnsignedThenAttachSignature(t *testing.T) {
+	ch := address.Address{}
+	params := types.VoucherCreateParams{TimeLock: 100}
+
+	unsigned := CreateUnsigned(ch, types.NewInt(42), 0, 1, params)
+	if unsigned.Signature != nil {
+		t.Fatal("expected an unsigned voucher to have no signature")
+	}
+	if unsigned.TimeLockMin != 100 {
+		t.Fatalf("expected CreateUnsigned to apply the given conditions, got %+v", unsigned)
+	}
+
+	if _, err := AttachSignature(unsigned, nil); err == nil {
+		t.Fatal("expected AttachSignature to reject a nil signature")
+	}
+
+	signed, err := AttachSignature(unsigned, &types.Signature{})
+	if err != nil {
+		t.Fatalf("unexpected error attaching signature: %s", err)
+	}
+	if signed.Signature == nil {
+		t.Fatal("expected the returned voucher to carry the signature")
+	}
+	if unsigned.Signature != nil {
+		t.Fatal("expected AttachSignature to leave the original voucher untouched")
+	}
+}
+
+func TestExportImportBundleRoundTripJSON(t *testing.T) {
+	testExportImportBundleRoundTrip(t, api.BundleFormatJSON)
+}
+
+func TestExportImportBundleRoundTripCBOR(t *testing.T) {
+	testExportImportBundleRoundTrip(t, api.BundleFormatCBOR)
+}
+
+func testExportImportBundleRoundTrip(t *testing.T, format api.BundleFormat) {
+	ch := address.Address{}
+	vouchers := []*types.SignedVoucher{
+		{Lane: 0, Nonce: 1, Amount: types.NewInt(10)},
+		{Lane: 1, Nonce: 2, Amount: types.NewInt(20)},
+	}
+
+	b, err := ExportBundle(ch, vouchers, format)
+	if err != nil {
+		t.Fatalf("unexpected error exporting bundle: %s", err)
+	}
+
+	bundle, err := ImportBundle(b, format)
+	if err != nil {
+		t.Fatalf("unexpected error importing bundle: %s", err)
+	}
+
+	if len(bundle.Vouchers) != len(vouchers) {
+		t.Fatalf("expected %d vouchers back, got %d", len(vouchers), len(bundle.Vouchers))
+	}
+	for i, v := range bundle.Vouchers {
+		if v.Lane != vouchers[i].Lane || v.Nonce != vouchers[i].Nonce {
+			t.Fatalf("voucher %d round-tripped incorrectly: got %+v, want %+v", i, v, vouchers[i])
+		}
+	}
+}
+
+func TestImportBundleRejectsUnknownFormat(t *testing.T) {
+	if _, err := ImportBundle([]byte("{}"), api.BundleFormat("yaml")); err == nil {
+		t.Fatal("expected an unknown bundle format to be rejected")
+	}
+}
@@ -0,0 +1,40 @@
+// Package paychmgr holds payment-channel logic shared by the full node
+// implementation: validating conditional vouchers, deciding what to
+// auto-submit as channels settle, and (de)serializing voucher state for
+// offline signing.
+package paychmgr
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+
+	"github.com/filecoin-project/go-lotus/chain/types"
+)
+
+// ValidateConditions checks the HTLC-style conditions a conditional voucher
+// may carry against the current chain height and a revealed secret. A
+// voucher that carries a secret hash is rejected unless secret is given and
+// its SHA-256 hash matches; secret is ignored for a voucher without one.
+func ValidateConditions(sv *types.SignedVoucher, height uint64, secret []byte) error {
+	if sv.TimeLockMin > 0 && height < sv.TimeLockMin {
+		return fmt.Errorf("voucher is time-locked until height %d, current height is %d", sv.TimeLockMin, height)
+	}
+
+	if sv.TimeLockMax > 0 && height > sv.TimeLockMax {
+		return fmt.Errorf("voucher expired at height %d, current height is %d", sv.TimeLockMax, height)
+	}
+
+	if len(sv.SecretPreimage) > 0 {
+		if len(secret) == 0 {
+			return fmt.Errorf("voucher requires a secret preimage to redeem")
+		}
+
+		h := sha256.Sum256(secret)
+		if !bytes.Equal(h[:], sv.SecretPreimage) {
+			return fmt.Errorf("secret preimage does not match voucher commitment")
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,81 @@
+package paychmgr
+
+import (
+	"context"
+	"crypto/sha256"
+	"testing"
+
+	"github.com/filecoin-project/go-lotus/chain/types"
+)
+
+func TestValidateConditionsTimeLockMin(t *testing.T) {
+	sv := &types.SignedVoucher{TimeLockMin: 100}
+
+	if err := ValidateConditions(sv, 50, nil); err == nil {
+		t.Fatal("expected an error before the time-lock has opened")
+	}
+
+	if err := ValidateConditions(sv, 150, nil); err != nil {
+		t.Fatalf("unexpected error once the time-lock has opened: %s", err)
+	}
+}
+
+func TestValidateConditionsTimeLockMax(t *testing.T) {
+	sv := &types.SignedVoucher{TimeLockMax: 100}
+
+	if err := ValidateConditions(sv, 150, nil); err == nil {
+		t.Fatal("expected an error once the voucher has expired")
+	}
+
+	if err := ValidateConditions(sv, 50, nil); err != nil {
+		t.Fatalf("unexpected error before expiry: %s", err)
+	}
+}
+
+func TestValidateConditionsSecretPreimage(t *testing.T) {
+	secret := []byte("open sesame")
+	hash := sha256.Sum256(secret)
+	sv := &types.SignedVoucher{SecretPreimage: hash[:]}
+
+	if err := ValidateConditions(sv, 0, nil); err == nil {
+		t.Fatal("expected an error redeeming without a secret")
+	}
+
+	if err := ValidateConditions(sv, 0, []byte("wrong secret")); err == nil {
+		t.Fatal("expected an error for a secret that doesn't match the commitment")
+	}
+
+	if err := ValidateConditions(sv, 0, secret); err != nil {
+		t.Fatalf("unexpected error for the correct secret: %s", err)
+	}
+}
+
+type fakeChainHeightAPI struct{ height uint64 }
+
+func (f fakeChainHeightAPI) ChainHead(ctx context.Context) (uint64, error) {
+	return f.height, nil
+}
+
+func TestManagerRejectsUnopenedTimeLock(t *testing.T) {
+	m := NewManager(fakeChainHeightAPI{height: 10})
+	sv := &types.SignedVoucher{TimeLockMin: 20}
+
+	if err := m.CheckVoucherValid(context.Background(), sv); err == nil {
+		t.Fatal("expected a time-locked voucher to be rejected")
+	}
+}
+
+func TestManagerSubmitChecksSecret(t *testing.T) {
+	m := NewManager(fakeChainHeightAPI{height: 10})
+	secret := []byte("s3cr3t")
+	hash := sha256.Sum256(secret)
+	sv := &types.SignedVoucher{SecretPreimage: hash[:]}
+
+	if err := m.SubmitVoucher(context.Background(), sv, []byte("wrong")); err == nil {
+		t.Fatal("expected submit to reject a mismatched secret")
+	}
+
+	if err := m.SubmitVoucher(context.Background(), sv, secret); err != nil {
+		t.Fatalf("unexpected error submitting with the correct secret: %s", err)
+	}
+}